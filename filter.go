@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// parseGlobList splits a comma-separated list of glob patterns, as
+// accepted by -namespaces, -exclude-namespaces, -gvk-include, and
+// -gvk-exclude.
+func parseGlobList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func globMatchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceFilter restricts a scan to a glob-matched set of namespaces.
+// Cluster-scoped objects (empty namespace) are never filtered.
+type NamespaceFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Allows reports whether objects in ns should be scanned.
+func (f NamespaceFilter) Allows(ns string) bool {
+	if ns == "" {
+		return true
+	}
+	if len(f.Include) > 0 && !globMatchAny(f.Include, ns) {
+		return false
+	}
+	return !globMatchAny(f.Exclude, ns)
+}
+
+// GVKFilter restricts a scan to a glob-matched set of resource kinds,
+// matched against "<group>/<version>/<kind>" (core resources use the
+// group name "core").
+type GVKFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func gvkString(gvk schema.GroupVersionKind) string {
+	group := gvk.Group
+	if group == "" {
+		group = "core"
+	}
+	return fmt.Sprintf("%v/%v/%v", group, gvk.Version, gvk.Kind)
+}
+
+// Allows reports whether objects of gvk should be scanned.
+func (f GVKFilter) Allows(gvk schema.GroupVersionKind) bool {
+	s := gvkString(gvk)
+	if len(f.Include) > 0 && !globMatchAny(f.Include, s) {
+		return false
+	}
+	return !globMatchAny(f.Exclude, s)
+}
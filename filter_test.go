@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestGlobMatchAny(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		s        string
+		want     bool
+	}{
+		{nil, "anything", false},
+		{[]string{"kube-*"}, "kube-system", true},
+		{[]string{"kube-*"}, "default", false},
+		{[]string{"foo", "kube-*"}, "kube-public", true},
+	}
+	for _, c := range cases {
+		if got := globMatchAny(c.patterns, c.s); got != c.want {
+			t.Errorf("globMatchAny(%v, %q) = %v, want %v", c.patterns, c.s, got, c.want)
+		}
+	}
+}
+
+func TestNamespaceFilterAllows(t *testing.T) {
+	f := NamespaceFilter{Include: []string{"app-*"}, Exclude: []string{"app-system"}}
+	if !f.Allows("app-frontend") {
+		t.Error("expected app-frontend to be allowed")
+	}
+	if f.Allows("app-system") {
+		t.Error("expected app-system to be excluded despite matching include")
+	}
+	if f.Allows("other") {
+		t.Error("expected other to be rejected: doesn't match include")
+	}
+	if !f.Allows("") {
+		t.Error("cluster-scoped objects (empty namespace) must never be filtered")
+	}
+}
+
+func TestGVKFilterAllows(t *testing.T) {
+	f := GVKFilter{Exclude: []string{"core/v1/Event"}}
+	pod := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}
+	event := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Event"}
+	if !f.Allows(pod) {
+		t.Error("expected Pod to be allowed")
+	}
+	if f.Allows(event) {
+		t.Error("expected core/v1/Event to be excluded")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import "k8s.io/apimachinery/pkg/types"
+
+// Severity indicates how serious a Finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding describes a single problem discovered while validating
+// ownerReferences in the cluster.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	RuleID   string   `json:"ruleId"`
+	Object   string   `json:"object"`
+	Owner    string   `json:"owner,omitempty"`
+	Message  string   `json:"message"`
+
+	// Cluster identifies the originating cluster in a multi-cluster
+	// scan (see -contexts/-kubeconfigs). It is empty for single-cluster
+	// scans.
+	Cluster string `json:"cluster,omitempty"`
+
+	// ObjectUID and RefUID identify the object and the offending
+	// ownerReference entry (by the UID it points at) so that -fix can
+	// locate and patch it without re-parsing Object/Owner text. They are
+	// not part of the serialized report.
+	ObjectUID types.UID `json:"-"`
+	RefUID    types.UID `json:"-"`
+}
+
+// Rule IDs for the individual checks performed during validation.
+const (
+	RuleMissingOwner        = "missing-owner"
+	RuleClusterOwnedByNS    = "cluster-owned-by-namespaced"
+	RuleCrossNamespaceOwner = "cross-namespace-owner"
+	RuleKindMismatch        = "kind-mismatch"
+	RuleNameMismatch        = "name-mismatch"
+	RuleAPIVersionMismatch  = "apiversion-mismatch"
+	RuleCycle               = "ownership-cycle"
+	RuleOrphanGroup         = "orphan-group"
+	RuleScopeMismatch       = "scope-mismatch"
+)
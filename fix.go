@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// FixPolicy identifies a class of Finding that -fix is permitted to
+// repair.
+type FixPolicy string
+
+const (
+	FixDropMissing             FixPolicy = "drop-missing"
+	FixDropCrossNamespace      FixPolicy = "drop-cross-namespace"
+	FixDropClusterToNamespaced FixPolicy = "drop-cluster-to-namespaced"
+)
+
+// rulePolicy maps a Finding's RuleID to the FixPolicy that authorizes
+// removing the ownerReference entry that triggered it.
+var rulePolicy = map[string]FixPolicy{
+	RuleMissingOwner:        FixDropMissing,
+	RuleCrossNamespaceOwner: FixDropCrossNamespace,
+	RuleClusterOwnedByNS:    FixDropClusterToNamespaced,
+}
+
+// parseFixPolicies splits a comma-separated -fix-policy value into a set
+// of enabled policies.
+func parseFixPolicies(s string) map[FixPolicy]bool {
+	policies := map[FixPolicy]bool{}
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			policies[FixPolicy(p)] = true
+		}
+	}
+	return policies
+}
+
+// ObjectFix is the set of ownerReference UIDs that should be removed
+// from a single object.
+type ObjectFix struct {
+	Object        ObjectDescription
+	RemoveRefUIDs []types.UID
+}
+
+// planFixes selects the findings that the enabled policies authorize
+// repairing and groups them into one ObjectFix per affected object.
+func planFixes(report Report, oc ObjectCatalog, policies map[FixPolicy]bool) []ObjectFix {
+	byObject := map[types.UID]*ObjectFix{}
+	var order []types.UID
+	for _, f := range report.Findings {
+		policy, ok := rulePolicy[f.RuleID]
+		if !ok || !policies[policy] || f.ObjectUID == "" || f.RefUID == "" {
+			continue
+		}
+		fx, ok := byObject[f.ObjectUID]
+		if !ok {
+			obj, found := oc[f.ObjectUID]
+			if !found {
+				continue
+			}
+			fx = &ObjectFix{Object: obj}
+			byObject[f.ObjectUID] = fx
+			order = append(order, f.ObjectUID)
+		}
+		fx.RemoveRefUIDs = append(fx.RemoveRefUIDs, f.RefUID)
+	}
+
+	fixes := make([]ObjectFix, 0, len(order))
+	for _, uid := range order {
+		fixes = append(fixes, *byObject[uid])
+	}
+	return fixes
+}
+
+// applyFix removes fx's planned ownerReference entries from the live
+// object via a merge patch. It always prints a diff of the change; when
+// apply is false the cluster is left untouched.
+func applyFix(ctx context.Context, cl client.Client, fx ObjectFix, apply bool) error {
+	remove := map[types.UID]bool{}
+	for _, uid := range fx.RemoveRefUIDs {
+		remove[uid] = true
+	}
+
+	gv, err := schema.ParseGroupVersion(fx.Object.APIVersion)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gv.WithKind(fx.Object.Kind))
+	u.SetNamespace(fx.Object.Namespace)
+	u.SetName(fx.Object.Name)
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(u), u); err != nil {
+		return err
+	}
+
+	before := u.GetOwnerReferences()
+	after := make([]v1.OwnerReference, 0, len(before))
+	for _, ref := range before {
+		if !remove[ref.UID] {
+			after = append(after, ref)
+		}
+	}
+
+	verb := "Would remove"
+	if apply {
+		verb = "Removing"
+	}
+	for _, ref := range before {
+		if remove[ref.UID] {
+			fmt.Printf("%v: %v ownerReference %v/%v (%v)\n", fx.Object.KindNamespaceName(), verb, ref.Kind, ref.Name, ref.UID)
+		}
+	}
+
+	if !apply {
+		return nil
+	}
+
+	patch := client.MergeFrom(u.DeepCopy())
+	u.SetOwnerReferences(after)
+	return cl.Patch(ctx, u, patch)
+}
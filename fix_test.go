@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPlanFixesPolicyGating(t *testing.T) {
+	obj := ObjectDescription{UID: "obj-1", Kind: "ReplicaSet", Name: "rs"}
+	oc := ObjectCatalog{obj.UID: obj}
+	report := Report{
+		Findings: []Finding{
+			{RuleID: RuleMissingOwner, ObjectUID: obj.UID, RefUID: "ref-1"},
+		},
+	}
+
+	if fixes := planFixes(report, oc, map[FixPolicy]bool{}); len(fixes) != 0 {
+		t.Fatalf("expected no fixes when drop-missing is disabled, got %v", fixes)
+	}
+
+	fixes := planFixes(report, oc, map[FixPolicy]bool{FixDropMissing: true})
+	if len(fixes) != 1 {
+		t.Fatalf("expected 1 fix when drop-missing is enabled, got %d", len(fixes))
+	}
+	if fixes[0].Object.UID != obj.UID {
+		t.Errorf("fix targets %v, want %v", fixes[0].Object.UID, obj.UID)
+	}
+	if got := fixes[0].RemoveRefUIDs; len(got) != 1 || got[0] != "ref-1" {
+		t.Errorf("RemoveRefUIDs = %v, want [ref-1]", got)
+	}
+}
+
+func TestPlanFixesGroupsMultipleFindingsPerObject(t *testing.T) {
+	obj := ObjectDescription{UID: "obj-1", Kind: "ReplicaSet", Name: "rs"}
+	oc := ObjectCatalog{obj.UID: obj}
+	report := Report{
+		Findings: []Finding{
+			{RuleID: RuleMissingOwner, ObjectUID: obj.UID, RefUID: "ref-1"},
+			{RuleID: RuleCrossNamespaceOwner, ObjectUID: obj.UID, RefUID: "ref-2"},
+			// No ObjectUID/RefUID: findings like RuleOrphanGroup aren't
+			// tied to a single ownerReference and must be ignored.
+			{RuleID: RuleOrphanGroup},
+		},
+	}
+
+	policies := map[FixPolicy]bool{FixDropMissing: true, FixDropCrossNamespace: true}
+	fixes := planFixes(report, oc, policies)
+	if len(fixes) != 1 {
+		t.Fatalf("expected findings on the same object to collapse into 1 fix, got %d", len(fixes))
+	}
+	want := []types.UID{"ref-1", "ref-2"}
+	got := fixes[0].RemoveRefUIDs
+	if len(got) != len(want) {
+		t.Fatalf("RemoveRefUIDs = %v, want %v", got, want)
+	}
+	for i, uid := range want {
+		if got[i] != uid {
+			t.Errorf("RemoveRefUIDs[%d] = %v, want %v", i, got[i], uid)
+		}
+	}
+}
+
+func TestPlanFixesSkipsFindingsForUncatalogedObjects(t *testing.T) {
+	oc := ObjectCatalog{}
+	report := Report{
+		Findings: []Finding{
+			{RuleID: RuleMissingOwner, ObjectUID: "missing", RefUID: "ref-1"},
+		},
+	}
+	if fixes := planFixes(report, oc, map[FixPolicy]bool{FixDropMissing: true}); len(fixes) != 0 {
+		t.Fatalf("expected no fixes for an object absent from the catalog, got %v", fixes)
+	}
+}
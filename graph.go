@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// WriteGraph renders oc as a directed ownerReference graph in the
+// requested format ("dot" or "mermaid").
+func WriteGraph(w io.Writer, format string, oc ObjectCatalog) error {
+	switch format {
+	case "dot":
+		return writeDot(w, oc)
+	case "mermaid":
+		return writeMermaid(w, oc)
+	default:
+		return fmt.Errorf("unknown graph format: %v", format)
+	}
+}
+
+func scopeColor(namespaced bool) string {
+	if namespaced {
+		return "lightblue"
+	}
+	return "lightgray"
+}
+
+func writeDot(w io.Writer, oc ObjectCatalog) error {
+	fmt.Fprintln(w, "digraph badref {")
+	for uid, obj := range oc {
+		fmt.Fprintf(w, "  %q [label=%q, style=filled, fillcolor=%q];\n", uid, obj.KindNamespaceName(), scopeColor(obj.IsNamespaced))
+	}
+	for uid, obj := range oc {
+		for _, ref := range obj.OwnerReferences {
+			controller := ref.Controller != nil && *ref.Controller
+			fmt.Fprintf(w, "  %q -> %q [controller=%v];\n", uid, ref.UID, controller)
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return nil
+}
+
+// mermaidID maps a UID to a Mermaid-safe node identifier.
+func mermaidID(uid types.UID) string {
+	return "n" + strings.ReplaceAll(string(uid), "-", "_")
+}
+
+func writeMermaid(w io.Writer, oc ObjectCatalog) error {
+	fmt.Fprintln(w, "graph TD")
+	for uid, obj := range oc {
+		fmt.Fprintf(w, "  %v[%q]:::%v\n", mermaidID(uid), obj.KindNamespaceName(), scopeClass(obj.IsNamespaced))
+	}
+	for uid, obj := range oc {
+		for _, ref := range obj.OwnerReferences {
+			arrow := "-->"
+			if ref.Controller != nil && *ref.Controller {
+				arrow = "==>"
+			}
+			fmt.Fprintf(w, "  %v %v %v\n", mermaidID(uid), arrow, mermaidID(ref.UID))
+		}
+	}
+	fmt.Fprintln(w, "  classDef namespaced fill:#add8e6")
+	fmt.Fprintln(w, "  classDef cluster fill:#d3d3d3")
+	return nil
+}
+
+func scopeClass(namespaced bool) string {
+	if namespaced {
+		return "namespaced"
+	}
+	return "cluster"
+}
+
+// cycleColor tracks DFS visitation state for detectCycles.
+type cycleColor int
+
+const (
+	white cycleColor = iota
+	gray
+	black
+)
+
+// detectCycles runs a white/gray/black DFS over the ownerReference graph
+// and reports a Finding for every back-edge found. Kubernetes GC assumes
+// the ownership graph is a DAG; a cycle leaves every object in it
+// permanently undeletable.
+func detectCycles(oc ObjectCatalog) []Finding {
+	colors := make(map[types.UID]cycleColor, len(oc))
+	var findings []Finding
+
+	var visit func(uid types.UID)
+	visit = func(uid types.UID) {
+		colors[uid] = gray
+		obj := oc[uid]
+		for _, ref := range obj.OwnerReferences {
+			owner, found := oc[ref.UID]
+			if !found {
+				continue
+			}
+			switch colors[ref.UID] {
+			case white:
+				visit(ref.UID)
+			case gray:
+				findings = append(findings, Finding{
+					Severity:  SeverityError,
+					RuleID:    RuleCycle,
+					Object:    obj.KindNamespaceName(),
+					Owner:     owner.KindNamespaceName(),
+					Message:   fmt.Sprintf("ownerReference cycle detected: %v -> %v", obj.KindNamespaceName(), owner.KindNamespaceName()),
+					ObjectUID: uid,
+					RefUID:    ref.UID,
+				})
+			case black:
+				// Already fully explored; no cycle through this edge.
+			}
+		}
+		colors[uid] = black
+	}
+
+	for uid := range oc {
+		if colors[uid] == white {
+			visit(uid)
+		}
+	}
+	return findings
+}
+
+// orphanGroupKey groups orphaned objects by their own kind and the
+// group and kind of the owner they were expecting but can no longer
+// find; the owner's API version is not part of the key.
+type orphanGroupKey struct {
+	objectKind string
+	ownerGroup string
+	ownerKind  string
+}
+
+// detectOrphans finds objects whose ownerReferences all point to
+// missing UIDs and groups them by (object kind, expected owner group and
+// kind), so
+// patterns like "47 ReplicaSets whose Deployment is gone" show up as a
+// single Finding instead of 47 individual ones.
+func detectOrphans(oc ObjectCatalog) []Finding {
+	counts := map[orphanGroupKey]int{}
+	for _, obj := range oc {
+		if len(obj.OwnerReferences) == 0 {
+			continue
+		}
+		allMissing := true
+		for _, ref := range obj.OwnerReferences {
+			if _, found := oc[ref.UID]; found {
+				allMissing = false
+				break
+			}
+		}
+		if !allMissing {
+			continue
+		}
+		for _, ref := range obj.OwnerReferences {
+			group := "core"
+			if gv, err := schema.ParseGroupVersion(ref.APIVersion); err == nil && gv.Group != "" {
+				group = gv.Group
+			}
+			counts[orphanGroupKey{objectKind: obj.Kind, ownerGroup: group, ownerKind: ref.Kind}]++
+		}
+	}
+
+	keys := make([]orphanGroupKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].objectKind != keys[j].objectKind {
+			return keys[i].objectKind < keys[j].objectKind
+		}
+		return keys[i].ownerKind < keys[j].ownerKind
+	})
+
+	findings := make([]Finding, 0, len(keys))
+	for _, k := range keys {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			RuleID:   RuleOrphanGroup,
+			Object:   k.objectKind,
+			Message:  fmt.Sprintf("%d %v objects have a missing owner of kind %v/%v", counts[k], k.objectKind, k.ownerGroup, k.ownerKind),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func ownerRef(uid string, controller bool) v1.OwnerReference {
+	c := controller
+	return v1.OwnerReference{UID: types.UID(uid), APIVersion: "v1", Kind: "ConfigMap", Name: uid, Controller: &c}
+}
+
+func TestDetectCyclesSelfLoop(t *testing.T) {
+	oc := ObjectCatalog{
+		"a": {UID: "a", Kind: "ConfigMap", Name: "a", OwnerReferences: []v1.OwnerReference{ownerRef("a", true)}},
+	}
+	findings := detectCycles(oc)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding for a self-loop, got %d: %v", len(findings), findings)
+	}
+	if findings[0].RuleID != RuleCycle {
+		t.Errorf("RuleID = %v, want %v", findings[0].RuleID, RuleCycle)
+	}
+}
+
+func TestDetectCyclesMultiNodeCycle(t *testing.T) {
+	oc := ObjectCatalog{
+		"a": {UID: "a", Kind: "ConfigMap", Name: "a", OwnerReferences: []v1.OwnerReference{ownerRef("b", true)}},
+		"b": {UID: "b", Kind: "ConfigMap", Name: "b", OwnerReferences: []v1.OwnerReference{ownerRef("c", true)}},
+		"c": {UID: "c", Kind: "ConfigMap", Name: "c", OwnerReferences: []v1.OwnerReference{ownerRef("a", true)}},
+	}
+	findings := detectCycles(oc)
+	if len(findings) != 1 {
+		t.Fatalf("expected exactly 1 back-edge finding for a 3-node cycle, got %d: %v", len(findings), findings)
+	}
+	if findings[0].RuleID != RuleCycle {
+		t.Errorf("RuleID = %v, want %v", findings[0].RuleID, RuleCycle)
+	}
+}
+
+func TestDetectCyclesNoCycle(t *testing.T) {
+	oc := ObjectCatalog{
+		"a": {UID: "a", Kind: "ConfigMap", Name: "a", OwnerReferences: []v1.OwnerReference{ownerRef("b", true)}},
+		"b": {UID: "b", Kind: "ConfigMap", Name: "b"},
+	}
+	if findings := detectCycles(oc); len(findings) != 0 {
+		t.Fatalf("expected no findings for a DAG, got %v", findings)
+	}
+}
+
+func TestDetectOrphansGroupsByKindAndMissingOwner(t *testing.T) {
+	missingOwner := v1.OwnerReference{UID: "gone", APIVersion: "apps/v1", Kind: "Deployment", Name: "gone"}
+	oc := ObjectCatalog{
+		"rs-1": {UID: "rs-1", Kind: "ReplicaSet", Name: "rs-1", OwnerReferences: []v1.OwnerReference{missingOwner}},
+		"rs-2": {UID: "rs-2", Kind: "ReplicaSet", Name: "rs-2", OwnerReferences: []v1.OwnerReference{missingOwner}},
+		// Has a present owner, so it must not count as an orphan.
+		"rs-3": {UID: "rs-3", Kind: "ReplicaSet", Name: "rs-3", OwnerReferences: []v1.OwnerReference{ownerRef("cm", true)}},
+		"cm":   {UID: "cm", Kind: "ConfigMap", Name: "cm"},
+	}
+	findings := detectOrphans(oc)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 grouped orphan finding, got %d: %v", len(findings), findings)
+	}
+	if findings[0].Message != "2 ReplicaSet objects have a missing owner of kind apps/Deployment" {
+		t.Errorf("unexpected message: %v", findings[0].Message)
+	}
+}
+
+func TestDetectOrphansIgnoresObjectsWithNoOwners(t *testing.T) {
+	oc := ObjectCatalog{
+		"a": {UID: "a", Kind: "ConfigMap", Name: "a"},
+	}
+	if findings := detectOrphans(oc); len(findings) != 0 {
+		t.Fatalf("expected no findings for an object with no ownerReferences, got %v", findings)
+	}
+}
@@ -25,14 +25,40 @@ import (
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
+var (
+	outputFormat = flag.String("output", "text", "Output format: text, json, or sarif")
+	outputFile   = flag.String("output-file", "", "File to write the report to (default: stdout)")
+	fixMode      = flag.String("fix", "", `Auto-repair mode: "dry-run" or "apply" (default: disabled)`)
+	fixPolicy    = flag.String("fix-policy", "", "Comma-separated repair policies to run: drop-missing,drop-cross-namespace,drop-cluster-to-namespaced")
+
+	workers        = flag.Int("workers", 4, "Number of concurrent discovery workers")
+	pageSize       = flag.Int64("page-size", 500, "Number of objects to request per list page")
+	qps            = flag.Float64("qps", 0, "Client-side rate limit in queries per second (0 uses the client-go default)")
+	burst          = flag.Int("burst", 0, "Client-side burst capacity (0 uses the client-go default)")
+	checkpointFile = flag.String("checkpoint", "", "Write the discovered ObjectCatalog to this file (ndjson) after scanning")
+	resumeFrom     = flag.String("resume-from", "", "Skip cluster discovery and validate the ObjectCatalog saved in this checkpoint file instead")
+
+	namespaces        = flag.String("namespaces", "", "Comma-separated glob patterns; only scan namespaces matching one of them")
+	excludeNamespaces = flag.String("exclude-namespaces", "", "Comma-separated glob patterns; skip namespaces matching any of them")
+	gvkInclude        = flag.String("gvk-include", "", `Comma-separated glob patterns over "group/version/kind" (core resources use group "core"); only scan matching resource kinds`)
+	gvkExclude        = flag.String("gvk-exclude", "", `Comma-separated glob patterns over "group/version/kind"; skip matching resource kinds`)
+	object            = flag.String("object", "", "Walk only the ownership chain rooted at <group>/<version>/<kind>/<namespace>/<name>, instead of scanning the whole cluster")
+
+	graphFormat = flag.String("graph", "", "Emit an ownerReference graph in addition to the validation report: dot or mermaid")
+	graphFile   = flag.String("graph-file", "", "File to write the graph to (default: stdout)")
+
+	contexts    = flag.String("contexts", "", "Comma-separated kubeconfig contexts to scan concurrently, merging into one report")
+	kubeconfigs = flag.String("kubeconfigs", "", "Comma-separated kubeconfig file paths to scan concurrently, pairing by index with -contexts")
+)
+
 type ObjectDescription struct {
 	APIVersion string
 	Kind       string
@@ -41,6 +67,12 @@ type ObjectDescription struct {
 	types.UID
 	OwnerReferences []v1.OwnerReference
 	IsNamespaced    bool
+
+	// ScopeMismatch is set when discovery's APIResource.Namespaced flag
+	// disagrees with the RESTMapper's view of the object's scope (IsNamespaced).
+	// It is always false for objects gathered by -object; objects loaded
+	// via -resume-from keep the value saved in the checkpoint.
+	ScopeMismatch bool
 }
 
 func (d ObjectDescription) KindNamespaceName() string {
@@ -65,121 +97,263 @@ func newObjectDescription(uo unstructured.Unstructured, namespaced bool) ObjectD
 
 type ObjectCatalog map[types.UID]ObjectDescription
 
-func main() {
-	flag.Parse()
-	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+// newClients builds the clientset and controller-runtime client used to
+// talk to the cluster, applying the -qps/-burst overrides to the rest
+// config.
+func newClients() (*kubernetes.Clientset, client.Client, error) {
 	config, err := ctrl.GetConfig()
 	if err != nil {
-		panic(err.Error())
+		return nil, nil, err
+	}
+	return clientsForConfig(config)
+}
+
+// clientsForConfig builds the clientset and controller-runtime client
+// for an already-resolved rest.Config, applying the -qps/-burst
+// overrides. Shared by newClients (current context) and newClientsFor
+// (multi-cluster scans).
+func clientsForConfig(config *rest.Config) (*kubernetes.Clientset, client.Client, error) {
+	if *qps > 0 {
+		config.QPS = float32(*qps)
+	}
+	if *burst > 0 {
+		config.Burst = *burst
 	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(err.Error())
+		return nil, nil, err
 	}
 	cl, err := client.New(config, client.Options{})
 	if err != nil {
-		panic(err.Error())
+		return nil, nil, err
 	}
+	return clientset, cl, nil
+}
 
-	// Load all the Kubernetes objects
-	oc := ObjectCatalog{}
-	numResources := 0
-	resources, err := clientset.ServerPreferredResources()
-	if err != nil {
-		panic(err.Error())
+// scanOptionsFromFlags builds the ScanOptions shared by single- and
+// multi-cluster scans from the -workers/-page-size/-namespaces/-gvk-*
+// flags.
+func scanOptionsFromFlags() ScanOptions {
+	return ScanOptions{
+		Workers:  *workers,
+		PageSize: *pageSize,
+		Namespaces: NamespaceFilter{
+			Include: parseGlobList(*namespaces),
+			Exclude: parseGlobList(*excludeNamespaces),
+		},
+		GVKs: GVKFilter{
+			Include: parseGlobList(*gvkInclude),
+			Exclude: parseGlobList(*gvkExclude),
+		},
 	}
-	for _, resourceList := range resources {
-		// fmt.Printf("Loading %v... ", resourceList.GroupVersion)
-		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+}
+
+func main() {
+	flag.Parse()
+	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	var oc ObjectCatalog
+	var cl client.Client
+	var report Report
+
+	targets := parseClusterTargets(*contexts, *kubeconfigs)
+
+	switch {
+	case len(targets) > 0:
+		if *checkpointFile != "" {
+			fmt.Println("Warning: -checkpoint is not supported for multi-cluster scans; skipping")
+		}
+		var err error
+		report, err = scanClusters(context.TODO(), targets, scanOptionsFromFlags())
 		if err != nil {
 			panic(err.Error())
 		}
-		for _, r := range resourceList.APIResources {
-			hasList := false
-			for _, v := range r.Verbs {
-				if v == "list" {
-					hasList = true
-				}
+	case *object != "":
+		ref, err := parseObjectRef(*object)
+		if err != nil {
+			panic(err.Error())
+		}
+		_, cl, err = newClients()
+		if err != nil {
+			panic(err.Error())
+		}
+		numResources := 0
+		oc, numResources, err = scanObject(context.TODO(), cl, ref)
+		if err != nil {
+			panic(err.Error())
+		}
+		report = validate(oc)
+		report.NumResources = numResources
+	case *resumeFrom != "":
+		var err error
+		oc, err = readCheckpoint(*resumeFrom)
+		if err != nil {
+			panic(err.Error())
+		}
+		report = validate(oc)
+		report.NumResources = len(oc)
+	default:
+		clientset, liveCl, err := newClients()
+		if err != nil {
+			panic(err.Error())
+		}
+		cl = liveCl
+
+		numResources := 0
+		oc, numResources, err = scanCluster(context.TODO(), clientset, cl, scanOptionsFromFlags())
+		if err != nil {
+			panic(err.Error())
+		}
+
+		if *checkpointFile != "" {
+			if err := writeCheckpoint(*checkpointFile, oc); err != nil {
+				panic(err.Error())
 			}
-			if hasList {
-				ul := &unstructured.UnstructuredList{}
-				ul.SetGroupVersionKind(schema.GroupVersionKind{
-					Group:   gv.Group,
-					Version: gv.Version,
-					Kind:    r.Kind,
-				})
-				err = cl.List(context.TODO(), ul)
+		}
+		report = validate(oc)
+		report.NumResources = numResources
+	}
+
+	if *graphFormat != "" {
+		if oc == nil {
+			fmt.Println("Warning: -graph is not supported for multi-cluster scans; skipping")
+		} else {
+			gout := os.Stdout
+			if *graphFile != "" {
+				f, err := os.Create(*graphFile)
 				if err != nil {
-					fmt.Printf("Error during list of %v: %v\n", ul.GroupVersionKind(), err)
-				}
-				for _, uo := range ul.Items {
-					numResources++
-					oc[uo.GetUID()] = newObjectDescription(uo, r.Namespaced)
+					panic(err.Error())
 				}
+				defer f.Close()
+				gout = f
+			}
+			if err := WriteGraph(gout, *graphFormat, oc); err != nil {
+				panic(err.Error())
+			}
+		}
+	}
+
+	out := os.Stdout
+	if *outputFile != "" {
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			panic(err.Error())
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := WriteReport(out, *outputFormat, report); err != nil {
+		panic(err.Error())
+	}
+
+	if *fixMode != "" {
+		if cl == nil {
+			panic("-fix requires a single live cluster connection; it cannot be combined with -resume-from or -contexts/-kubeconfigs")
+		}
+		if *namespaces != "" || *excludeNamespaces != "" || *gvkInclude != "" || *gvkExclude != "" {
+			panic("-fix cannot be combined with -namespaces/-exclude-namespaces/-gvk-include/-gvk-exclude: " +
+				"a filtered scan can't tell a genuinely missing owner from one merely excluded by the filter, " +
+				"and -fix would strip a perfectly valid ownerReference")
+		}
+		apply := *fixMode == "apply"
+		for _, fx := range planFixes(report, oc, parseFixPolicies(*fixPolicy)) {
+			if err := applyFix(context.TODO(), cl, fx, apply); err != nil {
+				fmt.Printf("Error fixing %v: %v\n", fx.Object.KindNamespaceName(), err)
 			}
 		}
 	}
-	fmt.Printf("Discovered %v resources\n", numResources)
 
-	// Validate owner references
-	foundErrors := false
-	checkedObj := 0
-	checkedOwners := 0
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+// validate checks the owner references of every object in oc and returns
+// a Report describing any problems found.
+func validate(oc ObjectCatalog) Report {
+	r := Report{}
 	for _, obj := range oc {
-		checkedObj++
-		// fmt.Printf("Checking: %v\n", obj.KindNamespaceName())
+		r.CheckedObjects++
+		if obj.ScopeMismatch {
+			r.Findings = append(r.Findings, Finding{
+				Severity:  SeverityWarning,
+				RuleID:    RuleScopeMismatch,
+				Object:    obj.KindNamespaceName(),
+				Message:   fmt.Sprintf("Discovery and the RESTMapper disagree on the scope of %v", obj.KindNamespaceName()),
+				ObjectUID: obj.UID,
+			})
+		}
 		for _, ref := range obj.OwnerReferences {
-			hasController := false
 			owner, found := oc[ref.UID]
 			if !found {
-				// The owner doesn't exist, so nothing to check
-				fmt.Printf("Warning: Couldn't find owner for %v/%v\n", obj.Namespace, obj.Name)
+				r.Findings = append(r.Findings, Finding{
+					Severity:  SeverityWarning,
+					RuleID:    RuleMissingOwner,
+					Object:    obj.KindNamespaceName(),
+					Message:   fmt.Sprintf("Couldn't find owner for %v/%v", obj.Namespace, obj.Name),
+					ObjectUID: obj.UID,
+					RefUID:    ref.UID,
+				})
 				continue
 			}
-			checkedOwners++
-			if !hasController {
-				hasController = true
-			} else {
-				foundErrors = true
-				fmt.Printf("ERROR: Object %v has more than 1 controller\n", obj.KindNamespaceName())
-			}
+			r.CheckedOwners++
 			// Check the rules
 			if !obj.IsNamespaced && owner.IsNamespaced {
-				foundErrors = true
-				fmt.Printf("ERROR: Non-namespaced %v is owned by namespaced %v\n",
-					obj.KindNamespaceName(), owner.KindNamespaceName())
+				r.Findings = append(r.Findings, Finding{
+					Severity:  SeverityError,
+					RuleID:    RuleClusterOwnedByNS,
+					Object:    obj.KindNamespaceName(),
+					Owner:     owner.KindNamespaceName(),
+					Message:   fmt.Sprintf("Non-namespaced %v is owned by namespaced %v", obj.KindNamespaceName(), owner.KindNamespaceName()),
+					ObjectUID: obj.UID,
+					RefUID:    ref.UID,
+				})
 			}
 			if obj.IsNamespaced && owner.IsNamespaced && obj.Namespace != owner.Namespace {
-				foundErrors = true
-				fmt.Printf("ERROR: namespaced %v is owned by object in another namespace %v\n",
-					obj.KindNamespaceName(), owner.KindNamespaceName())
+				r.Findings = append(r.Findings, Finding{
+					Severity:  SeverityError,
+					RuleID:    RuleCrossNamespaceOwner,
+					Object:    obj.KindNamespaceName(),
+					Owner:     owner.KindNamespaceName(),
+					Message:   fmt.Sprintf("namespaced %v is owned by object in another namespace %v", obj.KindNamespaceName(), owner.KindNamespaceName()),
+					ObjectUID: obj.UID,
+					RefUID:    ref.UID,
+				})
 			}
 			if !strings.EqualFold(ref.Kind, owner.Kind) {
-				foundErrors = true
-				fmt.Printf("Warning: In object %v, owner ref kind (%v) does not match owner %v (%v).\n",
-					obj.KindNamespaceName(), ref.Kind, owner.KindNamespaceName(), owner.Kind)
+				r.Findings = append(r.Findings, Finding{
+					Severity: SeverityWarning,
+					RuleID:   RuleKindMismatch,
+					Object:   obj.KindNamespaceName(),
+					Owner:    owner.KindNamespaceName(),
+					Message: fmt.Sprintf("In object %v, owner ref kind (%v) does not match owner %v (%v).",
+						obj.KindNamespaceName(), ref.Kind, owner.KindNamespaceName(), owner.Kind),
+				})
 			}
 			if !strings.EqualFold(ref.Name, owner.Name) {
-				foundErrors = true
-				fmt.Printf("Warning: In object %v, owner ref name (%v) does not match owner %v (%v).\n",
-					obj.KindNamespaceName(), ref.Name, owner.KindNamespaceName(), owner.Name)
+				r.Findings = append(r.Findings, Finding{
+					Severity: SeverityWarning,
+					RuleID:   RuleNameMismatch,
+					Object:   obj.KindNamespaceName(),
+					Owner:    owner.KindNamespaceName(),
+					Message: fmt.Sprintf("In object %v, owner ref name (%v) does not match owner %v (%v).",
+						obj.KindNamespaceName(), ref.Name, owner.KindNamespaceName(), owner.Name),
+				})
 			}
 			if !strings.EqualFold(ref.APIVersion, owner.APIVersion) {
-				foundErrors = true
-				fmt.Printf("Warning: In object %v, owner ref APIVersion (%v) does not match owner %v (%v).\n",
-					obj.KindNamespaceName(), ref.APIVersion, owner.KindNamespaceName(), owner.APIVersion)
+				r.Findings = append(r.Findings, Finding{
+					Severity: SeverityWarning,
+					RuleID:   RuleAPIVersionMismatch,
+					Object:   obj.KindNamespaceName(),
+					Owner:    owner.KindNamespaceName(),
+					Message: fmt.Sprintf("In object %v, owner ref APIVersion (%v) does not match owner %v (%v).",
+						obj.KindNamespaceName(), ref.APIVersion, owner.KindNamespaceName(), owner.APIVersion),
+				})
 			}
 		}
 	}
-
-	fmt.Printf("Scanned %v objects\n", checkedObj)
-	fmt.Printf("Checked %v owner references\n", checkedOwners)
-
-	if foundErrors {
-		fmt.Printf("=== ERRORS FOUND ===\n")
-		os.Exit(1)
-	} else {
-		fmt.Printf("All OK!\n")
-	}
+	r.Findings = append(r.Findings, detectCycles(oc)...)
+	r.Findings = append(r.Findings, detectOrphans(oc)...)
+	return r
 }
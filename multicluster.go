@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterTarget identifies one cluster to scan in a multi-cluster run.
+type ClusterTarget struct {
+	Name       string
+	Kubeconfig string
+	Context    string
+}
+
+// parseClusterTargets builds the list of clusters to scan from the
+// -contexts and -kubeconfigs flags. Entries pair up by index; if one
+// list is shorter, the missing side is left empty (current kubeconfig,
+// or its default context). A nil result means "scan the current
+// context only" via newClients.
+func parseClusterTargets(contexts, kubeconfigs string) []ClusterTarget {
+	ctxList := parseGlobList(contexts)
+	kcList := parseGlobList(kubeconfigs)
+	n := len(ctxList)
+	if len(kcList) > n {
+		n = len(kcList)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	targets := make([]ClusterTarget, 0, n)
+	for i := 0; i < n; i++ {
+		var ctx, kc string
+		if i < len(ctxList) {
+			ctx = ctxList[i]
+		}
+		if i < len(kcList) {
+			kc = kcList[i]
+		}
+		name := ctx
+		if name == "" {
+			name = kc
+		}
+		targets = append(targets, ClusterTarget{Name: name, Kubeconfig: kc, Context: ctx})
+	}
+	return targets
+}
+
+// restConfigFor resolves the rest.Config for a single cluster target by
+// loading its kubeconfig/context through client-go's standard loading
+// rules.
+func restConfigFor(t ClusterTarget) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if t.Kubeconfig != "" {
+		rules.ExplicitPath = t.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{}
+	if t.Context != "" {
+		overrides.CurrentContext = t.Context
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
+// newClientsFor builds the clientset and controller-runtime client for
+// a single cluster target, applying the -qps/-burst overrides.
+func newClientsFor(t ClusterTarget) (*kubernetes.Clientset, client.Client, error) {
+	config, err := restConfigFor(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientsForConfig(config)
+}
+
+// scanClusters scans every target concurrently and merges the results
+// into a single Report, tagging each Finding with its originating
+// cluster so findings stay distinguishable even when the same UID
+// happens to exist in more than one cluster. A cluster that fails to
+// scan doesn't abort the others; its error is reported but the
+// remaining clusters' findings are still merged into the result.
+func scanClusters(ctx context.Context, targets []ClusterTarget, opts ScanOptions) (Report, error) {
+	reports := make([]Report, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		i, t := i, t
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clientset, cl, err := newClientsFor(t)
+			if err != nil {
+				errs[i] = fmt.Errorf("cluster %v: %w", t.Name, err)
+				return
+			}
+			oc, numResources, err := scanCluster(ctx, clientset, cl, opts)
+			if err != nil {
+				errs[i] = fmt.Errorf("cluster %v: %w", t.Name, err)
+				return
+			}
+			report := validate(oc)
+			report.NumResources = numResources
+			for fi := range report.Findings {
+				report.Findings[fi].Cluster = t.Name
+			}
+			reports[i] = report
+		}()
+	}
+	wg.Wait()
+
+	merged := Report{}
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Printf("Error scanning cluster %v: %v\n", targets[i].Name, err)
+			continue
+		}
+		merged.NumResources += reports[i].NumResources
+		merged.CheckedObjects += reports[i].CheckedObjects
+		merged.CheckedOwners += reports[i].CheckedOwners
+		merged.Findings = append(merged.Findings, reports[i].Findings...)
+	}
+	if failed == len(targets) {
+		return Report{}, fmt.Errorf("all %d clusters failed to scan", len(targets))
+	}
+	return merged, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ObjectRef identifies a single Kubernetes object by GVK, namespace, and
+// name.
+type ObjectRef struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// parseObjectRef parses the -object flag's
+// "<group>/<version>/<kind>/<namespace>/<name>" form. Cluster-scoped
+// objects leave <namespace> empty, e.g. "rbac.authorization.k8s.io/v1/ClusterRole//my-role".
+// Core resources use the group name "core".
+func parseObjectRef(s string) (ObjectRef, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 5 {
+		return ObjectRef{}, fmt.Errorf("-object must have the form <group>/<version>/<kind>/<namespace>/<name>, got %q", s)
+	}
+	group := parts[0]
+	if group == "core" {
+		group = ""
+	}
+	return ObjectRef{
+		GVK:       schema.GroupVersionKind{Group: group, Version: parts[1], Kind: parts[2]},
+		Namespace: parts[3],
+		Name:      parts[4],
+	}, nil
+}
+
+// scanObject walks the ownership chain rooted at ref, fetching each
+// ancestor controller in turn, and returns a minimal ObjectCatalog
+// containing just that chain. It lets a single suspicious object be
+// triaged (e.g. "why won't this Deployment get GC'd?") without paying
+// for a full cluster scan.
+//
+// Each visited object's non-controller ownerReferences are also resolved
+// (but not walked further), so that validate doesn't mistake an owner
+// that simply falls outside the controller chain for one that's actually
+// missing from the cluster.
+func scanObject(ctx context.Context, cl client.Client, ref ObjectRef) (ObjectCatalog, int, error) {
+	oc := ObjectCatalog{}
+	visited := map[types.UID]bool{}
+
+	cur := ref
+	for {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(cur.GVK)
+		u.SetNamespace(cur.Namespace)
+		u.SetName(cur.Name)
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(u), u); err != nil {
+			return oc, len(oc), fmt.Errorf("getting %v %v/%v: %w", cur.GVK, cur.Namespace, cur.Name, err)
+		}
+
+		namespaced, err := apiutil.IsObjectNamespaced(u, cl.Scheme(), cl.RESTMapper())
+		if err != nil {
+			return oc, len(oc), err
+		}
+		desc := newObjectDescription(*u, namespaced)
+		oc[desc.UID] = desc
+		visited[desc.UID] = true
+
+		controller := controllerRef(desc.OwnerReferences)
+		for i := range desc.OwnerReferences {
+			other := &desc.OwnerReferences[i]
+			if other == controller || visited[other.UID] {
+				continue
+			}
+			if err := resolveOwnerRef(ctx, cl, cur.Namespace, *other, oc, visited); err != nil {
+				return oc, len(oc), err
+			}
+		}
+
+		if controller == nil || visited[controller.UID] {
+			return oc, len(oc), nil
+		}
+
+		ownerGV, err := schema.ParseGroupVersion(controller.APIVersion)
+		if err != nil {
+			return oc, len(oc), err
+		}
+		ownerGVK := ownerGV.WithKind(controller.Kind)
+
+		probe := &unstructured.Unstructured{}
+		probe.SetGroupVersionKind(ownerGVK)
+		ownerNamespaced, err := apiutil.IsObjectNamespaced(probe, cl.Scheme(), cl.RESTMapper())
+		if err != nil {
+			return oc, len(oc), err
+		}
+		ownerNS := ""
+		if ownerNamespaced {
+			// ownerReferences cannot cross namespaces, so a namespaced
+			// owner is always in the child's own namespace.
+			ownerNS = cur.Namespace
+		}
+		cur = ObjectRef{GVK: ownerGVK, Namespace: ownerNS, Name: controller.Name}
+	}
+}
+
+// resolveOwnerRef fetches the object referenced by ref (which belongs to
+// a child in namespace childNS) and, if found, records it in oc/visited.
+// A NotFound error is not propagated: leaving the ref unresolved means
+// validate will correctly report it as a genuinely missing owner.
+func resolveOwnerRef(ctx context.Context, cl client.Client, childNS string, ref v1.OwnerReference, oc ObjectCatalog, visited map[types.UID]bool) error {
+	ownerGV, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return err
+	}
+	ownerGVK := ownerGV.WithKind(ref.Kind)
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ownerGVK)
+	namespaced, err := apiutil.IsObjectNamespaced(u, cl.Scheme(), cl.RESTMapper())
+	if err != nil {
+		return err
+	}
+	if namespaced {
+		// ownerReferences cannot cross namespaces, so a namespaced owner
+		// is always in the child's own namespace.
+		u.SetNamespace(childNS)
+	}
+	u.SetName(ref.Name)
+
+	if err := cl.Get(ctx, client.ObjectKeyFromObject(u), u); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("getting %v %v/%v: %w", ownerGVK, childNS, ref.Name, err)
+	}
+
+	desc := newObjectDescription(*u, namespaced)
+	oc[desc.UID] = desc
+	visited[desc.UID] = true
+	return nil
+}
+
+// controllerRef returns the ownerReference marked as the managing
+// controller, falling back to the first entry if none is marked.
+func controllerRef(refs []v1.OwnerReference) *v1.OwnerReference {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return &refs[i]
+		}
+	}
+	if len(refs) > 0 {
+		return &refs[0]
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Report is the structured result of a badref scan.
+type Report struct {
+	NumResources   int       `json:"numResources"`
+	CheckedObjects int       `json:"checkedObjects"`
+	CheckedOwners  int       `json:"checkedOwners"`
+	Findings       []Finding `json:"findings"`
+}
+
+// HasErrors returns true if the report contains any Finding of
+// SeverityError.
+func (r Report) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteReport serializes r to w in the requested format ("text", "json",
+// or "sarif").
+func WriteReport(w io.Writer, format string, r Report) error {
+	switch format {
+	case "text":
+		return writeText(w, r)
+	case "json":
+		return writeJSON(w, r)
+	case "sarif":
+		return writeSARIF(w, r)
+	default:
+		return fmt.Errorf("unknown output format: %v", format)
+	}
+}
+
+func writeText(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "Discovered %v resources\n", r.NumResources)
+	for _, f := range r.Findings {
+		prefix := "Warning"
+		if f.Severity == SeverityError {
+			prefix = "ERROR"
+		}
+		if f.Cluster != "" {
+			prefix = fmt.Sprintf("%v[%v]", prefix, f.Cluster)
+		}
+		if f.Owner != "" {
+			fmt.Fprintf(w, "%v [%v]: %v (owner: %v)\n", prefix, f.RuleID, f.Message, f.Owner)
+		} else {
+			fmt.Fprintf(w, "%v [%v]: %v\n", prefix, f.RuleID, f.Message)
+		}
+	}
+	fmt.Fprintf(w, "Scanned %v objects\n", r.CheckedObjects)
+	fmt.Fprintf(w, "Checked %v owner references\n", r.CheckedOwners)
+	if r.HasErrors() {
+		fmt.Fprintf(w, "=== ERRORS FOUND ===\n")
+	} else {
+		fmt.Fprintf(w, "All OK!\n")
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// sarifLevel maps a Finding's Severity to the SARIF "level" property.
+func sarifLevel(s Severity) string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// The following types implement a minimal subset of the SARIF 2.1.0
+// schema sufficient to represent badref findings.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID  string       `json:"ruleId"`
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+func writeSARIF(w io.Writer, r Report) error {
+	ruleIDs := map[string]bool{}
+	results := make([]sarifResult, 0, len(r.Findings))
+	for _, f := range r.Findings {
+		if !ruleIDs[f.RuleID] {
+			ruleIDs[f.RuleID] = true
+		}
+		msg := f.Message
+		if f.Owner != "" {
+			msg = fmt.Sprintf("%v (owner: %v)", msg, f.Owner)
+		}
+		if f.Cluster != "" {
+			msg = fmt.Sprintf("[%v] %v", f.Cluster, msg)
+		}
+		results = append(results, sarifResult{
+			RuleID: f.RuleID,
+			Level:  sarifLevel(f.Severity),
+			Message: sarifMessage{
+				Text: msg,
+			},
+		})
+	}
+	sortedIDs := make([]string, 0, len(ruleIDs))
+	for id := range ruleIDs {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Strings(sortedIDs)
+
+	rules := make([]sarifRule, 0, len(sortedIDs))
+	for _, id := range sortedIDs {
+		rules = append(rules, sarifRule{ID: id})
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "badref",
+						InformationURI: "https://github.com/JohnStrunk/badref",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// catalogShards is the number of mutex-guarded shards a ShardedCatalog is
+// split into. It only needs to be large enough to keep worker goroutines
+// from contending on the same shard.
+const catalogShards = 32
+
+// ShardedCatalog is a concurrency-safe ObjectCatalog used while workers
+// are discovering objects in parallel. Reads are only safe once all
+// writers have finished; call ToObjectCatalog to get a plain snapshot.
+type ShardedCatalog struct {
+	shards [catalogShards]struct {
+		mu sync.Mutex
+		m  map[types.UID]ObjectDescription
+	}
+}
+
+// NewShardedCatalog returns an empty ShardedCatalog.
+func NewShardedCatalog() *ShardedCatalog {
+	sc := &ShardedCatalog{}
+	for i := range sc.shards {
+		sc.shards[i].m = map[types.UID]ObjectDescription{}
+	}
+	return sc
+}
+
+func (sc *ShardedCatalog) shardFor(uid types.UID) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uid))
+	return int(h.Sum32() % catalogShards)
+}
+
+// Set records desc under its UID.
+func (sc *ShardedCatalog) Set(desc ObjectDescription) {
+	s := &sc.shards[sc.shardFor(desc.UID)]
+	s.mu.Lock()
+	s.m[desc.UID] = desc
+	s.mu.Unlock()
+}
+
+// ToObjectCatalog flattens the shards into a plain ObjectCatalog.
+func (sc *ShardedCatalog) ToObjectCatalog() ObjectCatalog {
+	oc := ObjectCatalog{}
+	for i := range sc.shards {
+		s := &sc.shards[i]
+		s.mu.Lock()
+		for uid, desc := range s.m {
+			oc[uid] = desc
+		}
+		s.mu.Unlock()
+	}
+	return oc
+}
+
+// scanTask is a single GVK that a worker should list and catalog.
+type scanTask struct {
+	gvk schema.GroupVersionKind
+	// discoveryNamespaced is the scope discovery reported for gvk
+	// (APIResource.Namespaced), kept alongside the RESTMapper's own
+	// answer so scanGVK can flag the two disagreeing.
+	discoveryNamespaced bool
+}
+
+// ScanOptions controls how scanCluster discovers and catalogs objects.
+type ScanOptions struct {
+	Workers    int
+	PageSize   int64
+	Namespaces NamespaceFilter
+	GVKs       GVKFilter
+}
+
+// scanCluster discovers every listable resource type in the cluster and
+// catalogs their objects, using a bounded pool of workers that page
+// through each resource type with client.Limit/client.Continue.
+func scanCluster(ctx context.Context, clientset *kubernetes.Clientset, cl client.Client, opts ScanOptions) (ObjectCatalog, int, error) {
+	if opts.Workers < 1 {
+		return nil, 0, fmt.Errorf("scanCluster: opts.Workers must be at least 1, got %d", opts.Workers)
+	}
+
+	resources, err := clientset.ServerPreferredResources()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tasks := make(chan scanTask)
+	catalog := NewShardedCatalog()
+	var numResources int64
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < opts.Workers; i++ {
+		g.Go(func() error {
+			for t := range tasks {
+				if err := scanGVK(gctx, cl, t.gvk, t.discoveryNamespaced, opts, catalog, &numResources); err != nil {
+					fmt.Printf("Error during list of %v: %v\n", t.gvk, err)
+				}
+			}
+			return nil
+		})
+	}
+
+	for _, resourceList := range resources {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			close(tasks)
+			_ = g.Wait()
+			return nil, 0, err
+		}
+		for _, r := range resourceList.APIResources {
+			hasList := false
+			for _, v := range r.Verbs {
+				if v == "list" {
+					hasList = true
+				}
+			}
+			if !hasList {
+				continue
+			}
+			gvk := schema.GroupVersionKind{Group: gv.Group, Version: gv.Version, Kind: r.Kind}
+			if !opts.GVKs.Allows(gvk) {
+				continue
+			}
+			tasks <- scanTask{gvk: gvk, discoveryNamespaced: r.Namespaced}
+		}
+	}
+	close(tasks)
+
+	if err := g.Wait(); err != nil {
+		return nil, 0, err
+	}
+
+	return catalog.ToObjectCatalog(), int(numResources), nil
+}
+
+// scanGVK resolves the scope of gvk via the RESTMapper and pages through
+// every object of that kind, recording each one that passes
+// opts.Namespaces in catalog. discoveryNamespaced is compared against the
+// RESTMapper's answer so objects of a GVK whose scope disagrees between
+// discovery and the mapper (e.g. a CRD registered mid-scan) can be
+// flagged via ObjectDescription.ScopeMismatch.
+func scanGVK(ctx context.Context, cl client.Client, gvk schema.GroupVersionKind, discoveryNamespaced bool, opts ScanOptions, catalog *ShardedCatalog, numResources *int64) error {
+	probe := &unstructured.Unstructured{}
+	probe.SetGroupVersionKind(gvk)
+	namespaced, err := apiutil.IsObjectNamespaced(probe, cl.Scheme(), cl.RESTMapper())
+	if err != nil {
+		return err
+	}
+	scopeMismatch := namespaced != discoveryNamespaced
+
+	cont := ""
+	for {
+		ul := &unstructured.UnstructuredList{}
+		ul.SetGroupVersionKind(gvk)
+		listOpts := []client.ListOption{client.Limit(opts.PageSize)}
+		if cont != "" {
+			listOpts = append(listOpts, client.Continue(cont))
+		}
+		if err := cl.List(ctx, ul, listOpts...); err != nil {
+			return err
+		}
+		for _, uo := range ul.Items {
+			if !opts.Namespaces.Allows(uo.GetNamespace()) {
+				continue
+			}
+			desc := newObjectDescription(uo, namespaced)
+			desc.ScopeMismatch = scopeMismatch
+			catalog.Set(desc)
+			atomic.AddInt64(numResources, 1)
+		}
+		cont = ul.GetContinue()
+		if cont == "" {
+			return nil
+		}
+	}
+}
+
+// writeCheckpoint saves oc to path as newline-delimited JSON so a later
+// run can validate it offline via -resume-from, without hitting the API
+// server again.
+func writeCheckpoint(path string, oc ObjectCatalog) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, desc := range oc {
+		if err := enc.Encode(desc); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// readCheckpoint loads an ObjectCatalog previously written by
+// writeCheckpoint.
+func readCheckpoint(path string) (ObjectCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	oc := ObjectCatalog{}
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var desc ObjectDescription
+		if err := dec.Decode(&desc); err != nil {
+			return nil, err
+		}
+		oc[desc.UID] = desc
+	}
+	return oc, nil
+}